@@ -0,0 +1,15 @@
+// Package antibot fournit des vérificateurs anti-bot interchangeables pour le
+// formulaire de contact. Turnstile (Cloudflare) est utilisé par défaut ; un
+// CAPTCHA image intégré sert de repli lorsque Turnstile est injoignable,
+// typiquement dans des réseaux ou navigateurs qui bloquent les challenges
+// Cloudflare.
+package antibot
+
+import "context"
+
+// Verifier vérifie qu'une soumission provient bien d'un humain. Le format de
+// token dépend de l'implémentation : un jeton Turnstile pour
+// TurnstileVerifier, ou un "id:solution" pour CaptchaVerifier.
+type Verifier interface {
+	Verify(ctx context.Context, token, ip string) (bool, error)
+}
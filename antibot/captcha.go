@@ -0,0 +1,42 @@
+package antibot
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dchest/captcha"
+)
+
+// CaptchaVerifier est le repli lorsque Turnstile est indisponible : un
+// CAPTCHA image classique, généré et vérifié en mémoire par
+// github.com/dchest/captcha. Le token attendu par Verify est "id:solution",
+// tel que posté par le formulaire dans les champs captcha-id/captcha-solution.
+type CaptchaVerifier struct{}
+
+// NewCaptchaVerifier construit un CaptchaVerifier.
+func NewCaptchaVerifier() *CaptchaVerifier {
+	return &CaptchaVerifier{}
+}
+
+// New génère un nouveau challenge et retourne son id, à servir ensuite via
+// WriteImage.
+func (v *CaptchaVerifier) New() string {
+	return captcha.New()
+}
+
+// WriteImage écrit l'image PNG du challenge id sur w.
+func (v *CaptchaVerifier) WriteImage(w io.Writer, id string) error {
+	return captcha.WriteImage(w, id, captcha.StdWidth, captcha.StdHeight)
+}
+
+// Verify découpe token en "id:solution" et délègue à captcha.VerifyString, qui
+// consomme le challenge : un id ne peut être vérifié qu'une seule fois.
+func (v *CaptchaVerifier) Verify(ctx context.Context, token, ip string) (bool, error) {
+	id, solution, ok := strings.Cut(token, ":")
+	if !ok {
+		return false, fmt.Errorf("antibot: jeton captcha invalide")
+	}
+	return captcha.VerifyString(id, solution), nil
+}
@@ -0,0 +1,28 @@
+package antibot
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// NewChallengeHandler répond à /api/captcha/new avec l'id d'un nouveau
+// challenge et l'URL de son image, à servir ensuite par ImageHandler.
+func (v *CaptchaVerifier) NewChallengeHandler(w http.ResponseWriter, r *http.Request) {
+	id := v.New()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		ID       string `json:"id"`
+		ImageURL string `json:"image_url"`
+	}{ID: id, ImageURL: "/api/captcha/" + id + ".png"})
+}
+
+// ImageHandler sert l'image PNG du challenge dont l'id figure dans le chemin
+// /api/captcha/{id}.png.
+func (v *CaptchaVerifier) ImageHandler(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/captcha/"), ".png")
+	w.Header().Set("Content-Type", "image/png")
+	if err := v.WriteImage(w, id); err != nil {
+		http.Error(w, "Challenge introuvable.", http.StatusNotFound)
+	}
+}
@@ -0,0 +1,65 @@
+package antibot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const defaultTurnstileVerifyURL = "https://challenges.cloudflare.com/turnstile/v0/siteverify"
+
+// TurnstileVerifier délègue la vérification au service siteverify de
+// Cloudflare Turnstile.
+type TurnstileVerifier struct {
+	secret    string
+	verifyURL string
+	client    *http.Client
+}
+
+// NewTurnstileVerifier construit un TurnstileVerifier pour secret, la clé
+// secrète du site Turnstile (TURNSTILE_SECRET).
+func NewTurnstileVerifier(secret string) *TurnstileVerifier {
+	return NewTurnstileVerifierWithURL(secret, defaultTurnstileVerifyURL)
+}
+
+// NewTurnstileVerifierWithURL construit un TurnstileVerifier pointant vers
+// verifyURL plutôt que l'API Cloudflare, ce qui permet de le pointer vers un
+// httptest.Server qui stub siteverify dans les tests.
+func NewTurnstileVerifierWithURL(secret, verifyURL string) *TurnstileVerifier {
+	return &TurnstileVerifier{secret: secret, verifyURL: verifyURL, client: http.DefaultClient}
+}
+
+// Verify poste token à l'API siteverify et retourne son verdict success.
+func (v *TurnstileVerifier) Verify(ctx context.Context, token, ip string) (bool, error) {
+	if v.secret == "" {
+		return false, fmt.Errorf("antibot: TURNSTILE_SECRET non défini")
+	}
+
+	body := url.Values{
+		"secret":   {v.secret},
+		"response": {token},
+		"remoteip": {ip},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.verifyURL, strings.NewReader(body.Encode()))
+	if err != nil {
+		return false, fmt.Errorf("antibot: requête siteverify: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("antibot: requête siteverify: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var data struct {
+		Success bool `json:"success"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return false, fmt.Errorf("antibot: décodage réponse siteverify: %w", err)
+	}
+	return data.Success, nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	cases := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		want       string
+	}{
+		{"remote addr only", "203.0.113.9:4532", nil, "203.0.113.9"},
+		{"x-forwarded-for from trusted proxy", "10.0.0.1:4532", map[string]string{"X-Forwarded-For": "203.0.113.9"}, "203.0.113.9"},
+		{"x-forwarded-for chain takes first", "10.0.0.1:4532", map[string]string{"X-Forwarded-For": "203.0.113.9, 10.0.0.2"}, "203.0.113.9"},
+		{"x-real-ip fallback", "10.0.0.1:4532", map[string]string{"X-Real-IP": "203.0.113.9"}, "203.0.113.9"},
+		{"x-forwarded-for takes priority over x-real-ip", "10.0.0.1:4532", map[string]string{"X-Forwarded-For": "203.0.113.9", "X-Real-IP": "198.51.100.1"}, "203.0.113.9"},
+		{"x-forwarded-for ignored from untrusted remote", "198.51.100.50:4532", map[string]string{"X-Forwarded-For": "203.0.113.9"}, "198.51.100.50"},
+		{"x-real-ip ignored from untrusted remote", "198.51.100.50:4532", map[string]string{"X-Real-IP": "203.0.113.9"}, "198.51.100.50"},
+	}
+
+	old := trustedProxies
+	trustedProxies = parseTrustedProxies("10.0.0.0/8")
+	defer func() { trustedProxies = old }()
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/contact", nil)
+			req.RemoteAddr = tc.remoteAddr
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			if got := clientIP(req); got != tc.want {
+				t.Errorf("clientIP() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
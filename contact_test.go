@@ -0,0 +1,397 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SebbeMercier/Eldocam_Back_end/antibot"
+	"github.com/SebbeMercier/Eldocam_Back_end/courier"
+	"github.com/SebbeMercier/Eldocam_Back_end/internal/mocksmtp"
+	"github.com/SebbeMercier/Eldocam_Back_end/ratelimit"
+)
+
+// fakeSMSDispatcher capture les Message envoyés sur le canal "sms", pour
+// vérifier qu'une alerte urgente est bien enfilée sans dépendre d'un vrai
+// fournisseur SMS.
+type fakeSMSDispatcher struct {
+	mu   sync.Mutex
+	sent []courier.Message
+}
+
+func (d *fakeSMSDispatcher) Send(ctx context.Context, msg courier.Message) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.sent = append(d.sent, msg)
+	return nil
+}
+
+func (d *fakeSMSDispatcher) messages() []courier.Message {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	out := make([]courier.Message, len(d.sent))
+	copy(out, d.sent)
+	return out
+}
+
+// setupContactTest branche courierQueue sur un mocksmtp.Server en mémoire et
+// un Turnstile stub dont la réponse est contrôlée par turnstileOK, puis
+// restaure l'état global à la fin du test. Chaque test reçoit son propre
+// MemoryLimiter (10 requêtes par 15 minutes).
+func setupContactTest(t *testing.T, turnstileOK bool) *mocksmtp.Server {
+	t.Helper()
+
+	mock, err := mocksmtp.Start()
+	if err != nil {
+		t.Fatalf("mocksmtp.Start: %v", err)
+	}
+	t.Cleanup(func() { mock.Close() })
+
+	dispatcher, err := courier.NewSMTPDispatcher("smtp://bot:secret@" + mock.Addr)
+	if err != nil {
+		t.Fatalf("NewSMTPDispatcher: %v", err)
+	}
+
+	dbPath := filepath.Join(t.TempDir(), "courier.db")
+	queue, err := courier.NewBoltQueue(dbPath, map[string]courier.Dispatcher{"smtp": dispatcher})
+	if err != nil {
+		t.Fatalf("NewBoltQueue: %v", err)
+	}
+	t.Cleanup(func() { queue.Close() })
+
+	prevQueue := courierQueue
+	courierQueue = queue
+	t.Cleanup(func() { courierQueue = prevQueue })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go queue.Run(ctx)
+	t.Cleanup(cancel)
+
+	prevLimiter := rateLimiter
+	rateLimiter = ratelimit.NewMemoryLimiter(ctx, 10, 15*time.Minute)
+	t.Cleanup(func() { rateLimiter = prevLimiter })
+
+	turnstile := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]bool{"success": turnstileOK})
+	}))
+	t.Cleanup(turnstile.Close)
+
+	prevVerifier := turnstileVerifier
+	turnstileVerifier = antibot.NewTurnstileVerifierWithURL("test-secret", turnstile.URL)
+	t.Cleanup(func() { turnstileVerifier = prevVerifier })
+
+	os.Setenv("MAIL_USER", "bot")
+	os.Setenv("ADMIN_TO", "admin@example.com")
+
+	return mock
+}
+
+// postContact envoie un ContactForm en JSON à contactHandler, avec le token
+// Turnstile en query (FormValue ne lit pas le corps JSON) et ip comme
+// adresse distante pour le rate limiter.
+func postContact(ip string, form map[string]string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(form)
+	req := httptest.NewRequest(http.MethodPost, "/api/contact?cf-turnstile-response=tok", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = ip
+
+	rec := httptest.NewRecorder()
+	contactHandler(rec, req)
+	return rec
+}
+
+// decodeResponse désérialise l'enveloppe JSON renvoyée par contactHandler.
+func decodeResponse(t *testing.T, rec *httptest.ResponseRecorder) apiResponse {
+	t.Helper()
+	var resp apiResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("réponse non JSON: %v (body = %s)", err, rec.Body.String())
+	}
+	return resp
+}
+
+// waitForMessage attend qu'un message soit arrivé dans le mock SMTP pour to,
+// le courierQueue le traitant en arrière-plan via son worker.
+func waitForMessage(t *testing.T, mock *mocksmtp.Server, to string) mocksmtp.Message {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, msg := range mock.Messages() {
+			if len(msg.To) > 0 && msg.To[0] == to {
+				return msg
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("aucun message reçu pour %s", to)
+	return mocksmtp.Message{}
+}
+
+func TestContactHandler_ValidSubmission(t *testing.T) {
+	mock := setupContactTest(t, true)
+
+	rec := postContact("198.51.100.1:1234", map[string]string{
+		"Name":     "Alice Dupont",
+		"Email":    "alice@example.com",
+		"Message":  "Bonjour, je voudrais un devis.",
+		"Language": "fr",
+	})
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	resp := decodeResponse(t, rec)
+	if !resp.OK || resp.Message != "Votre message a bien été envoyé." {
+		t.Errorf("resp = %+v", resp)
+	}
+
+	waitForMessage(t, mock, "alice@example.com")
+	waitForMessage(t, mock, "admin@example.com")
+}
+
+func TestContactHandler_MissingFields(t *testing.T) {
+	setupContactTest(t, true)
+
+	rec := postContact("198.51.100.2:1234", map[string]string{
+		"Message": "Bonjour",
+	})
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400, body = %s", rec.Code, rec.Body.String())
+	}
+	resp := decodeResponse(t, rec)
+	if resp.Code != codeValidationFailed {
+		t.Errorf("code = %q, want %q", resp.Code, codeValidationFailed)
+	}
+	if resp.Fields["Name"] == "" || resp.Fields["Email"] == "" {
+		t.Errorf("fields = %v, want des entrées pour Name et Email", resp.Fields)
+	}
+}
+
+func TestContactHandler_RejectsURLInMessage(t *testing.T) {
+	setupContactTest(t, true)
+
+	rec := postContact("198.51.100.3:1234", map[string]string{
+		"Name":    "Bob Martin",
+		"Email":   "bob@example.com",
+		"Message": "Voir http://example.com pour plus d'infos",
+	})
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400, body = %s", rec.Code, rec.Body.String())
+	}
+	if resp := decodeResponse(t, rec); resp.Code != codeLinksNotAllowed {
+		t.Errorf("code = %q, want %q", resp.Code, codeLinksNotAllowed)
+	}
+}
+
+func TestContactHandler_RateLimitExhausted(t *testing.T) {
+	setupContactTest(t, true)
+
+	ip := "198.51.100.4:1234"
+	form := map[string]string{
+		"Name":    "Carole Petit",
+		"Email":   "carole@example.com",
+		"Message": "Bonjour, une question.",
+	}
+
+	for i := 0; i < 10; i++ {
+		rec := postContact(ip, form)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("requête %d: status = %d, body = %s", i, rec.Code, rec.Body.String())
+		}
+	}
+
+	rec := postContact(ip, form)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429, body = %s", rec.Code, rec.Body.String())
+	}
+	if resp := decodeResponse(t, rec); resp.Code != codeRateLimited {
+		t.Errorf("code = %q, want %q", resp.Code, codeRateLimited)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("en-tête Retry-After manquant")
+	}
+	if rec.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Errorf("X-RateLimit-Remaining = %q, want \"0\"", rec.Header().Get("X-RateLimit-Remaining"))
+	}
+}
+
+func TestContactHandler_TurnstileFailure(t *testing.T) {
+	setupContactTest(t, false)
+
+	rec := postContact("198.51.100.5:1234", map[string]string{
+		"Name":    "David Leroy",
+		"Email":   "david@example.com",
+		"Message": "Bonjour",
+	})
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400, body = %s", rec.Code, rec.Body.String())
+	}
+	if resp := decodeResponse(t, rec); resp.Code != codeTurnstileFailed {
+		t.Errorf("code = %q, want %q", resp.Code, codeTurnstileFailed)
+	}
+}
+
+// TestContactHandler_CaptchaFallback vérifie que le repli CAPTCHA image est
+// bien essayé quand le client n'envoie pas de jeton Turnstile, et rejeté
+// quand le challenge qu'il référence n'a jamais été émis par
+// /api/captcha/new.
+func TestContactHandler_CaptchaFallback(t *testing.T) {
+	setupContactTest(t, true)
+
+	body, _ := json.Marshal(map[string]string{
+		"Name":    "Fatima Nkosi",
+		"Email":   "fatima@example.com",
+		"Message": "Bonjour",
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/contact?captcha-id=inconnu&captcha-solution=123456", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = "198.51.100.7:1234"
+
+	rec := httptest.NewRecorder()
+	contactHandler(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400, body = %s", rec.Code, rec.Body.String())
+	}
+	if resp := decodeResponse(t, rec); resp.Code != codeCaptchaFailed {
+		t.Errorf("code = %q, want %q", resp.Code, codeCaptchaFailed)
+	}
+}
+
+// TestContactHandler_UrgentMessageTriggersSMS vérifie qu'un message contenant
+// un mot-clé de courier.UrgentKeywords déclenche une alerte SMS admin en plus
+// des emails habituels, quand un canal "sms" est configuré et ADMIN_PHONE
+// renseigné.
+func TestContactHandler_UrgentMessageTriggersSMS(t *testing.T) {
+	mock := setupContactTest(t, true)
+
+	sms := &fakeSMSDispatcher{}
+	prevQueue := courierQueue
+	dbPath := filepath.Join(t.TempDir(), "courier-sms.db")
+	dispatcher, err := courier.NewSMTPDispatcher("smtp://bot:secret@" + mock.Addr)
+	if err != nil {
+		t.Fatalf("NewSMTPDispatcher: %v", err)
+	}
+	queue, err := courier.NewBoltQueue(dbPath, map[string]courier.Dispatcher{"smtp": dispatcher, "sms": sms})
+	if err != nil {
+		t.Fatalf("NewBoltQueue: %v", err)
+	}
+	t.Cleanup(func() { queue.Close() })
+	courierQueue = queue
+	t.Cleanup(func() { courierQueue = prevQueue })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go queue.Run(ctx)
+	t.Cleanup(cancel)
+
+	os.Setenv("ADMIN_PHONE", "+32470000000")
+	t.Cleanup(func() { os.Unsetenv("ADMIN_PHONE") })
+
+	rec := postContact("198.51.100.8:1234", map[string]string{
+		"Name":    "Gilles Renard",
+		"Email":   "gilles@example.com",
+		"Message": "Urgent, fuite d'eau dans la cave",
+	})
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	for len(sms.messages()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	msgs := sms.messages()
+	if len(msgs) != 1 {
+		t.Fatalf("alertes sms = %d, want 1", len(msgs))
+	}
+	if msgs[0].To != "+32470000000" {
+		t.Errorf("To = %q, want %q", msgs[0].To, "+32470000000")
+	}
+	if !strings.Contains(msgs[0].TextBody, "Gilles Renard") {
+		t.Errorf("TextBody ne mentionne pas l'auteur: %s", msgs[0].TextBody)
+	}
+}
+
+// TestContactHandler_NonUrgentMessageSkipsSMS vérifie qu'un message sans
+// mot-clé urgent n'enfile aucune alerte SMS, même quand le canal est
+// configuré.
+func TestContactHandler_NonUrgentMessageSkipsSMS(t *testing.T) {
+	mock := setupContactTest(t, true)
+
+	sms := &fakeSMSDispatcher{}
+	prevQueue := courierQueue
+	dbPath := filepath.Join(t.TempDir(), "courier-sms.db")
+	dispatcher, err := courier.NewSMTPDispatcher("smtp://bot:secret@" + mock.Addr)
+	if err != nil {
+		t.Fatalf("NewSMTPDispatcher: %v", err)
+	}
+	queue, err := courier.NewBoltQueue(dbPath, map[string]courier.Dispatcher{"smtp": dispatcher, "sms": sms})
+	if err != nil {
+		t.Fatalf("NewBoltQueue: %v", err)
+	}
+	t.Cleanup(func() { queue.Close() })
+	courierQueue = queue
+	t.Cleanup(func() { courierQueue = prevQueue })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go queue.Run(ctx)
+	t.Cleanup(cancel)
+
+	os.Setenv("ADMIN_PHONE", "+32470000000")
+	t.Cleanup(func() { os.Unsetenv("ADMIN_PHONE") })
+
+	rec := postContact("198.51.100.9:1234", map[string]string{
+		"Name":    "Hugo Simon",
+		"Email":   "hugo@example.com",
+		"Message": "Bonjour, une simple question sur vos tarifs.",
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+
+	waitForMessage(t, mock, "hugo@example.com")
+
+	if got := len(sms.messages()); got != 0 {
+		t.Fatalf("alertes sms = %d, want 0", got)
+	}
+}
+
+func TestContactHandler_AutoReplyLanguage(t *testing.T) {
+	mock := setupContactTest(t, true)
+
+	rec := postContact("198.51.100.6:1234", map[string]string{
+		"Name":     "Eva Van den Berg",
+		"Email":    "eva@example.com",
+		"Message":  "Goedendag",
+		"Language": "nl",
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	resp := decodeResponse(t, rec)
+	if !resp.OK || resp.Message != "Je bericht is goed ontvangen." {
+		t.Errorf("resp = %+v", resp)
+	}
+
+	msg := waitForMessage(t, mock, "eva@example.com")
+	data := string(msg.Data)
+	if !strings.Contains(data, fmt.Sprintf("Subject: %s", "Automatisch antwoord")) {
+		t.Errorf("sujet néerlandais manquant: %s", data)
+	}
+	if !strings.Contains(data, "Hallo Eva Van den Berg,") {
+		t.Errorf("corps néerlandais manquant: %s", data)
+	}
+}
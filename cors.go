@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"strings"
+)
+
+// corsMiddleware autorise les origines listées dans ALLOWED_ORIGINS (liste
+// séparée par des virgules ; une entrée "*.example.com" accepte tout
+// sous-domaine de example.com) et répond directement aux préflights OPTIONS.
+func corsMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); origin != "" && originAllowed(origin, os.Getenv("ALLOWED_ORIGINS")) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Vary", "Origin")
+			w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		}
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// originAllowed vérifie si origin (ex: "https://app.example.com") correspond
+// à l'une des entrées de allowedRaw. Une entrée peut être une origine exacte
+// ou, préfixée par "*.", accepter tout sous-domaine de son suffixe.
+func originAllowed(origin, allowedRaw string) bool {
+	host := strings.TrimPrefix(strings.TrimPrefix(origin, "https://"), "http://")
+
+	for _, allowed := range strings.Split(allowedRaw, ",") {
+		allowed = strings.TrimSpace(allowed)
+		if allowed == "" {
+			continue
+		}
+		if allowed == origin {
+			return true
+		}
+		if strings.HasPrefix(allowed, "*.") {
+			suffix := allowed[len("*."):]
+			if host == suffix || strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestOriginAllowed(t *testing.T) {
+	cases := []struct {
+		origin  string
+		allowed string
+		want    bool
+	}{
+		{"https://eldocam.be", "https://eldocam.be", true},
+		{"https://eldocam.be", "https://autre.example", false},
+		{"https://app.eldocam.be", "*.eldocam.be", true},
+		{"https://eldocam.be", "*.eldocam.be", true},
+		{"https://evil.com", "*.eldocam.be", false},
+		{"https://eldocam.be", "https://app.eldocam.be, *.eldocam.be", true},
+		{"https://eldocam.be", "", false},
+	}
+
+	for _, tc := range cases {
+		if got := originAllowed(tc.origin, tc.allowed); got != tc.want {
+			t.Errorf("originAllowed(%q, %q) = %v, want %v", tc.origin, tc.allowed, got, tc.want)
+		}
+	}
+}
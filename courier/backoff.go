@@ -0,0 +1,16 @@
+package courier
+
+import "time"
+
+// backoff calcule un délai exponentiel plafonné avant le prochain essai.
+// attempt 0 -> base, attempt 1 -> base*2, ... jusqu'à max.
+func backoff(attempt int, base, max time.Duration) time.Duration {
+	d := base
+	for i := 0; i < attempt; i++ {
+		d *= 2
+		if d >= max {
+			return max
+		}
+	}
+	return d
+}
@@ -0,0 +1,50 @@
+// Package courier dispatches outbound notifications (email, SMS, ...) through
+// pluggable channels and survives process restarts via a persisted queue.
+package courier
+
+import (
+	"context"
+	"time"
+)
+
+// Priority marque l'importance d'un message. Elle ne change pas le canal
+// utilisé, qui est fixé par Channel ; l'appelant peut s'en servir pour
+// enfiler un message supplémentaire (ex: une alerte SMS en plus de l'email).
+type Priority string
+
+const (
+	PriorityNormal Priority = "normal"
+	PriorityUrgent Priority = "urgent"
+)
+
+// Message est l'unité dispatchée par un Dispatcher, qu'il s'agisse d'un mail
+// SMTP, d'un email transactionnel HTTP ou d'un SMS.
+//
+// TextBody est toujours renseigné (c'est le seul corps utilisé par le canal
+// "sms"). HTMLBody est optionnel ; quand les deux sont présents, les canaux
+// email envoient un MIME multipart/alternative avec les deux parts.
+type Message struct {
+	ID       string
+	Channel  string
+	To       string
+	From     string
+	ReplyTo  string
+	Subject  string
+	TextBody string
+	HTMLBody string
+	Priority Priority
+
+	CreatedAt time.Time
+	Attempts  int
+}
+
+// Dispatcher envoie un Message sur un canal donné (SMTP, Mailgun, Twilio...).
+type Dispatcher interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Queue met en file les messages et les rejoue jusqu'à succès ou abandon.
+type Queue interface {
+	Enqueue(ctx context.Context, msg Message) error
+	Close() error
+}
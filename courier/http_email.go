@@ -0,0 +1,61 @@
+package courier
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// HTTPEmailDispatcher envoie des emails transactionnels via une API HTTP
+// compatible Mailgun/Postmark plutôt qu'un relais SMTP direct.
+type HTTPEmailDispatcher struct {
+	client   *http.Client
+	endpoint string
+	apiKey   string
+	from     string
+}
+
+// NewHTTPEmailDispatcher construit un dispatcher ciblant endpoint (l'URL de
+// l'API Mailgun/Postmark du domaine) avec apiKey pour l'authentification.
+func NewHTTPEmailDispatcher(endpoint, apiKey, from string) *HTTPEmailDispatcher {
+	return &HTTPEmailDispatcher{
+		client:   http.DefaultClient,
+		endpoint: endpoint,
+		apiKey:   apiKey,
+		from:     from,
+	}
+}
+
+// Send poste msg à l'API transactionnelle configurée, en form-urlencoded
+// dans le corps de la requête (format attendu par Mailgun/Postmark).
+func (d *HTTPEmailDispatcher) Send(ctx context.Context, msg Message) error {
+	form := url.Values{
+		"from":    {d.from},
+		"to":      {msg.To},
+		"subject": {msg.Subject},
+		"text":    {msg.TextBody},
+	}
+	if msg.HTMLBody != "" {
+		form.Set("html", msg.HTMLBody)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth("api", d.apiKey)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("courier: envoi http_email échoué: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("courier: http_email a répondu %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package courier
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestHTTPEmailDispatcherSend_PostsFormEncodedBody(t *testing.T) {
+	var gotContentType string
+	var gotBody string
+	var gotAuthOK bool
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotContentType = r.Header.Get("Content-Type")
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		_, pass, ok := r.BasicAuth()
+		gotAuthOK = ok && pass == "key-123"
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	d := NewHTTPEmailDispatcher(server.URL, "key-123", "bot@example.com")
+	msg := Message{To: "client@example.com", Subject: "Bonjour", TextBody: "Merci de votre message"}
+	if err := d.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if gotContentType != "application/x-www-form-urlencoded" {
+		t.Errorf("Content-Type = %q, want application/x-www-form-urlencoded", gotContentType)
+	}
+	if gotBody == "" {
+		t.Fatal("request body was empty, fields were sent as query params instead")
+	}
+	if !gotAuthOK {
+		t.Error("expected Basic Auth with the API key")
+	}
+
+	form, err := url.ParseQuery(gotBody)
+	if err != nil {
+		t.Fatalf("parsing body: %v", err)
+	}
+	if form.Get("to") != "client@example.com" || form.Get("subject") != "Bonjour" || form.Get("text") != "Merci de votre message" {
+		t.Errorf("unexpected form body: %+v", form)
+	}
+}
+
+func TestHTTPEmailDispatcherSend_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	d := NewHTTPEmailDispatcher(server.URL, "key-123", "bot@example.com")
+	err := d.Send(context.Background(), Message{To: "client@example.com"})
+	if err == nil {
+		t.Fatal("expected an error on a non-2xx response")
+	}
+}
@@ -0,0 +1,152 @@
+package courier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var pendingBucket = []byte("pending")
+
+// BoltQueue persiste les messages en attente dans un fichier BoltDB, de sorte
+// qu'un redémarrage du process ne perde pas les soumissions en cours de
+// traitement. Un unique worker goroutine consomme la file via Run.
+type BoltQueue struct {
+	db       *bolt.DB
+	routes   map[string]Dispatcher
+	base     time.Duration
+	max      time.Duration
+	maxTries int
+}
+
+// NewBoltQueue ouvre (ou crée) la base BoltDB à path et prépare la file.
+// routes associe un nom de canal ("smtp", "sms", "http_email") au
+// Dispatcher chargé de l'envoyer effectivement.
+func NewBoltQueue(path string, routes map[string]Dispatcher) (*BoltQueue, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 2 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("ouverture file courier: %w", err)
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(pendingBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltQueue{
+		db:       db,
+		routes:   routes,
+		base:     2 * time.Second,
+		max:      5 * time.Minute,
+		maxTries: 8,
+	}, nil
+}
+
+// HasRoute indique si un Dispatcher est configuré pour channel, pour que
+// l'appelant puisse décider d'enfiler ou non un message sur ce canal (ex: ne
+// pas tenter d'alerte SMS si COURIER_SMS_PROVIDER n'est pas défini).
+func (q *BoltQueue) HasRoute(channel string) bool {
+	_, ok := q.routes[channel]
+	return ok
+}
+
+// Enqueue persiste msg puis retourne immédiatement ; l'envoi effectif est
+// fait plus tard par Run, ce qui libère le handler HTTP appelant.
+func (q *BoltQueue) Enqueue(ctx context.Context, msg Message) error {
+	if msg.CreatedAt.IsZero() {
+		msg.CreatedAt = time.Now()
+	}
+	raw, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return q.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(pendingBucket).Put([]byte(msg.ID), raw)
+	})
+}
+
+// Run draine la file jusqu'à ce que ctx soit annulé, en retentant chaque
+// message avec un backoff exponentiel plafonné jusqu'à maxTries.
+func (q *BoltQueue) Run(ctx context.Context) {
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			q.drainOnce(ctx)
+		}
+	}
+}
+
+func (q *BoltQueue) drainOnce(ctx context.Context) {
+	var msgs []Message
+	_ = q.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(pendingBucket).ForEach(func(k, v []byte) error {
+			var m Message
+			if err := json.Unmarshal(v, &m); err == nil {
+				msgs = append(msgs, m)
+			}
+			return nil
+		})
+	})
+
+	now := time.Now()
+	for _, msg := range msgs {
+		if msg.CreatedAt.After(now) {
+			continue // en attente de son délai de backoff
+		}
+
+		dispatcher, ok := q.routes[msg.Channel]
+		if !ok {
+			log.Printf("courier: aucun dispatcher pour le canal %q, message %s abandonné", msg.Channel, msg.ID)
+			q.remove(msg.ID)
+			continue
+		}
+
+		if err := dispatcher.Send(ctx, msg); err != nil {
+			msg.Attempts++
+			if msg.Attempts >= q.maxTries {
+				log.Printf("courier: message %s abandonné après %d tentatives: %v", msg.ID, msg.Attempts, err)
+				q.remove(msg.ID)
+				continue
+			}
+			log.Printf("courier: envoi de %s échoué (tentative %d): %v", msg.ID, msg.Attempts, err)
+			q.reschedule(msg)
+			continue
+		}
+		q.remove(msg.ID)
+	}
+}
+
+// reschedule ré-enregistre le message avec son nombre de tentatives à jour et
+// attend le délai de backoff avant de le représenter au prochain tick.
+func (q *BoltQueue) reschedule(msg Message) {
+	delay := backoff(msg.Attempts, q.base, q.max)
+	msg.CreatedAt = time.Now().Add(delay)
+	raw, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	_ = q.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(pendingBucket).Put([]byte(msg.ID), raw)
+	})
+}
+
+func (q *BoltQueue) remove(id string) {
+	_ = q.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(pendingBucket).Delete([]byte(id))
+	})
+}
+
+// Close ferme le fichier BoltDB sous-jacent.
+func (q *BoltQueue) Close() error {
+	return q.db.Close()
+}
@@ -0,0 +1,155 @@
+package courier
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+var errTemporaire = errors.New("échec temporaire")
+
+// countingDispatcher compte les tentatives d'envoi par message et échoue sur
+// les failUntil premiers appels pour chaque message, pour simuler un
+// dispatcher ponctuellement en panne.
+type countingDispatcher struct {
+	mu        sync.Mutex
+	attempts  map[string]int
+	failUntil int
+}
+
+func newCountingDispatcher(failUntil int) *countingDispatcher {
+	return &countingDispatcher{attempts: map[string]int{}, failUntil: failUntil}
+}
+
+func (d *countingDispatcher) Send(ctx context.Context, msg Message) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.attempts[msg.ID]++
+	if d.attempts[msg.ID] <= d.failUntil {
+		return errTemporaire
+	}
+	return nil
+}
+
+func (d *countingDispatcher) count(id string) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.attempts[id]
+}
+
+func TestBoltQueue_PersistsAcrossRestart(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "courier.db")
+
+	dispatcher := newCountingDispatcher(0)
+	q1, err := NewBoltQueue(dbPath, map[string]Dispatcher{"smtp": dispatcher})
+	if err != nil {
+		t.Fatalf("NewBoltQueue: %v", err)
+	}
+	if err := q1.Enqueue(context.Background(), Message{ID: "m1", Channel: "smtp", To: "a@example.com"}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	// Le process s'arrête avant que le worker n'ait pu drainer le message.
+	if err := q1.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	q2, err := NewBoltQueue(dbPath, map[string]Dispatcher{"smtp": dispatcher})
+	if err != nil {
+		t.Fatalf("NewBoltQueue (reopen): %v", err)
+	}
+	defer q2.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	deadline := time.Now().Add(2 * time.Second)
+	for dispatcher.count("m1") == 0 && time.Now().Before(deadline) {
+		q2.drainOnce(ctx)
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if dispatcher.count("m1") != 1 {
+		t.Fatalf("message persisté non redrainé après redémarrage, attempts = %d", dispatcher.count("m1"))
+	}
+}
+
+func TestBoltQueue_ReschedulesWithBackoffUntilSuccess(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "courier.db")
+	dispatcher := newCountingDispatcher(2)
+	q, err := NewBoltQueue(dbPath, map[string]Dispatcher{"smtp": dispatcher})
+	if err != nil {
+		t.Fatalf("NewBoltQueue: %v", err)
+	}
+	defer q.Close()
+	q.base = time.Millisecond
+	q.max = 10 * time.Millisecond
+
+	if err := q.Enqueue(context.Background(), Message{ID: "m1", Channel: "smtp", To: "a@example.com"}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	ctx := context.Background()
+	deadline := time.Now().Add(2 * time.Second)
+	for dispatcher.count("m1") < 3 && time.Now().Before(deadline) {
+		q.drainOnce(ctx)
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if got := dispatcher.count("m1"); got != 3 {
+		t.Fatalf("attempts = %d, want 3 (2 échecs puis succès)", got)
+	}
+
+	q.drainOnce(ctx)
+	if got := dispatcher.count("m1"); got != 3 {
+		t.Fatalf("message relivré après succès: attempts = %d, want toujours 3", got)
+	}
+}
+
+func TestBoltQueue_AbandonsAfterMaxTries(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "courier.db")
+	dispatcher := newCountingDispatcher(1000) // échoue toujours
+	q, err := NewBoltQueue(dbPath, map[string]Dispatcher{"smtp": dispatcher})
+	if err != nil {
+		t.Fatalf("NewBoltQueue: %v", err)
+	}
+	defer q.Close()
+	q.base = time.Millisecond
+	q.max = time.Millisecond
+	q.maxTries = 3
+
+	if err := q.Enqueue(context.Background(), Message{ID: "m1", Channel: "smtp", To: "a@example.com"}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	ctx := context.Background()
+	deadline := time.Now().Add(2 * time.Second)
+	for dispatcher.count("m1") < q.maxTries && time.Now().Before(deadline) {
+		q.drainOnce(ctx)
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Une tentative de plus ne devrait rien faire : le message doit avoir été
+	// retiré de la file après maxTries échecs.
+	q.drainOnce(ctx)
+	if got := dispatcher.count("m1"); got != q.maxTries {
+		t.Fatalf("attempts = %d, want %d (abandon après maxTries)", got, q.maxTries)
+	}
+}
+
+func TestBoltQueue_HasRoute(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "courier.db")
+	q, err := NewBoltQueue(dbPath, map[string]Dispatcher{"smtp": newCountingDispatcher(0)})
+	if err != nil {
+		t.Fatalf("NewBoltQueue: %v", err)
+	}
+	defer q.Close()
+
+	if !q.HasRoute("smtp") {
+		t.Error("HasRoute(\"smtp\") = false, want true")
+	}
+	if q.HasRoute("sms") {
+		t.Error("HasRoute(\"sms\") = true, want false")
+	}
+}
@@ -0,0 +1,81 @@
+package courier
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// SMSDispatcher envoie des alertes courtes (ex: message urgent détecté) via
+// l'API HTTP d'un fournisseur SMS. provider sélectionne le format de requête :
+// "twilio" (Basic Auth + From/To/Body) ou "vonage" (api_key/api_secret dans le
+// corps + from/to/text), car les deux APIs n'attendent ni la même
+// authentification ni les mêmes noms de champs.
+type SMSDispatcher struct {
+	client     *http.Client
+	provider   string
+	endpoint   string
+	accountSID string
+	authToken  string
+	from       string
+}
+
+// NewSMSDispatcher construit un dispatcher SMS pour provider ("twilio" ou
+// "vonage"). endpoint est l'URL complète de l'API du fournisseur (ex.
+// l'endpoint Messages de Twilio, ou https://rest.nexmo.com/sms/json pour
+// Vonage). Pour Twilio, accountSID/authToken sont l'Account SID et l'Auth
+// Token utilisés en Basic Auth ; pour Vonage, ce sont l'api_key et
+// l'api_secret envoyés dans le corps de la requête.
+func NewSMSDispatcher(provider, endpoint, accountSID, authToken, from string) *SMSDispatcher {
+	return &SMSDispatcher{
+		client:     http.DefaultClient,
+		provider:   provider,
+		endpoint:   endpoint,
+		accountSID: accountSID,
+		authToken:  authToken,
+		from:       from,
+	}
+}
+
+// Send poste msg comme SMS. msg.Subject et msg.HTMLBody sont ignorés, seul
+// msg.TextBody est envoyé.
+func (d *SMSDispatcher) Send(ctx context.Context, msg Message) error {
+	var form url.Values
+	if d.provider == "vonage" {
+		form = url.Values{
+			"api_key":    {d.accountSID},
+			"api_secret": {d.authToken},
+			"from":       {d.from},
+			"to":         {msg.To},
+			"text":       {msg.TextBody},
+		}
+	} else {
+		form = url.Values{
+			"From": {d.from},
+			"To":   {msg.To},
+			"Body": {msg.TextBody},
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if d.provider != "vonage" {
+		req.SetBasicAuth(d.accountSID, d.authToken)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("courier: envoi sms échoué: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("courier: fournisseur sms a répondu %s", resp.Status)
+	}
+	return nil
+}
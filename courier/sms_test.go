@@ -0,0 +1,94 @@
+package courier
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestSMSDispatcherSend_Twilio(t *testing.T) {
+	var gotContentType, gotBody string
+	var gotAuthOK bool
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotContentType = r.Header.Get("Content-Type")
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		user, pass, ok := r.BasicAuth()
+		gotAuthOK = ok && user == "sid" && pass == "token"
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	d := NewSMSDispatcher("twilio", server.URL, "sid", "token", "+3200000000")
+	if err := d.Send(context.Background(), Message{To: "+3211111111", TextBody: "urgent!"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if gotContentType != "application/x-www-form-urlencoded" {
+		t.Errorf("Content-Type = %q, want application/x-www-form-urlencoded", gotContentType)
+	}
+	if !gotAuthOK {
+		t.Error("expected Basic Auth with the Twilio Account SID/Auth Token")
+	}
+
+	form, err := url.ParseQuery(gotBody)
+	if err != nil {
+		t.Fatalf("parsing body: %v", err)
+	}
+	if form.Get("To") != "+3211111111" || form.Get("Body") != "urgent!" || form.Get("From") != "+3200000000" {
+		t.Errorf("unexpected form body: %+v", form)
+	}
+}
+
+func TestSMSDispatcherSend_Vonage(t *testing.T) {
+	var gotContentType, gotBody string
+	var hadBasicAuth bool
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotContentType = r.Header.Get("Content-Type")
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		_, _, hadBasicAuth = r.BasicAuth()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	d := NewSMSDispatcher("vonage", server.URL, "api-key", "api-secret", "+3200000000")
+	if err := d.Send(context.Background(), Message{To: "+3211111111", TextBody: "urgent!"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if gotContentType != "application/x-www-form-urlencoded" {
+		t.Errorf("Content-Type = %q, want application/x-www-form-urlencoded", gotContentType)
+	}
+	if hadBasicAuth {
+		t.Error("Vonage authenticates via api_key/api_secret in the body, not Basic Auth")
+	}
+
+	form, err := url.ParseQuery(gotBody)
+	if err != nil {
+		t.Fatalf("parsing body: %v", err)
+	}
+	if form.Get("api_key") != "api-key" || form.Get("api_secret") != "api-secret" {
+		t.Errorf("missing Vonage credentials in body: %+v", form)
+	}
+	if form.Get("to") != "+3211111111" || form.Get("text") != "urgent!" || form.Get("from") != "+3200000000" {
+		t.Errorf("unexpected form body: %+v", form)
+	}
+}
+
+func TestSMSDispatcherSend_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	d := NewSMSDispatcher("twilio", server.URL, "sid", "token", "+3200000000")
+	if err := d.Send(context.Background(), Message{To: "+3211111111", TextBody: "urgent!"}); err == nil {
+		t.Fatal("expected an error on a non-2xx response")
+	}
+}
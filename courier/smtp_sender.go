@@ -0,0 +1,18 @@
+package courier
+
+import "net/smtp"
+
+// SMTPSender abstrait l'appel réseau de net/smtp.SendMail, pour que
+// SMTPDispatcher puisse être testé contre un serveur SMTP factice (voir
+// internal/mocksmtp) sans toucher ssl0.ovh.net.
+type SMTPSender interface {
+	SendMail(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// netSMTPSender est l'implémentation de production, un simple adaptateur
+// vers net/smtp.SendMail.
+type netSMTPSender struct{}
+
+func (netSMTPSender) SendMail(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+	return smtp.SendMail(addr, auth, from, to, msg)
+}
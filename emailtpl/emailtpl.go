@@ -0,0 +1,134 @@
+// Package emailtpl rend les emails admin/auto-réponse à partir de templates
+// text/template embarqués, un répertoire par langue, avec repli sur le
+// français lorsque la langue demandée n'est pas supportée.
+package emailtpl
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"golang.org/x/text/language"
+)
+
+//go:embed templates
+var builtin embed.FS
+
+const defaultLang = "fr"
+
+var supported = []language.Tag{
+	language.French,
+	language.Dutch,
+	language.English,
+}
+
+var matcher = language.NewMatcher(supported)
+
+// langDirs associe chaque tag supporté au nom de son répertoire de templates.
+var langDirs = map[language.Tag]string{
+	language.French:  "fr",
+	language.Dutch:   "nl",
+	language.English: "en",
+}
+
+// Data regroupe les variables exposées aux templates.
+type Data struct {
+	// Kind vaut "admin" ou "autoreply" ; utilisé par subject.txt.gotmpl pour
+	// choisir le bon objet.
+	Kind    string
+	Name    string
+	Email   string
+	Tel     string
+	Message string
+}
+
+var tagRegexp = regexp.MustCompile(`<[^>]+>`)
+
+// ResolveLanguage fait correspondre requested (un code BCP-47 tel que "nl"
+// ou "en-US") au meilleur répertoire de templates supporté, avec repli sur
+// le français par défaut.
+func ResolveLanguage(requested string) string {
+	tag, err := language.Parse(requested)
+	if err != nil {
+		return defaultLang
+	}
+	_, index, confidence := matcher.Match(tag)
+	if confidence == language.No {
+		return defaultLang
+	}
+	return langDirs[supported[index]]
+}
+
+// Render charge le template name (sans suffixe) pour lang et retourne
+// l'objet, le corps HTML et son équivalent texte brut. Si
+// EMAIL_TEMPLATES_DIR est défini, les fichiers qui s'y trouvent prennent le
+// pas sur ceux embarqués dans le binaire.
+func Render(lang, name string, data Data) (subject, htmlBody, textBody string, err error) {
+	data.Kind = name
+
+	subject, err = renderOne(lang, "subject.txt.gotmpl", data)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	htmlBody, err = renderOne(lang, name+".html.gotmpl", data)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	if name == "autoreply" {
+		// L'auto-réponse est un vrai template HTML dont les données sont
+		// pré-échappées par l'appelant (cf. buildMessages) : on peut retirer
+		// les balises sans risque puis décoder les entités (&amp;, &lt;, ...)
+		// qu'elles encodaient, pour que la part texte affiche "&" et non
+		// littéralement "&amp;" dans le client mail.
+		textBody = strings.TrimSpace(html.UnescapeString(tagRegexp.ReplaceAllString(htmlBody, "")))
+	} else {
+		// Le mail admin est du texte brut non échappé (cf. buildMessages) :
+		// il peut légitimement contenir "<...>" (ex: une adresse email entre
+		// chevrons) que tagRegexp supprimerait à tort. htmlBody est déjà le
+		// texte à envoyer tel quel.
+		textBody = strings.TrimSpace(htmlBody)
+	}
+	return strings.TrimSpace(subject), htmlBody, textBody, nil
+}
+
+func renderOne(lang, file string, data Data) (string, error) {
+	tpl, err := loadTemplate(lang, file)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("emailtpl: exécution de %s/%s: %w", lang, file, err)
+	}
+	return buf.String(), nil
+}
+
+// loadTemplate lit templates/<lang>/<file>, en privilégiant le répertoire
+// d'override disque (EMAIL_TEMPLATES_DIR) puis en repliant sur les fichiers
+// embarqués, et enfin sur la langue par défaut si lang n'a pas de fichier.
+func loadTemplate(lang, file string) (*template.Template, error) {
+	if dir := os.Getenv("EMAIL_TEMPLATES_DIR"); dir != "" {
+		path := filepath.Join(dir, lang, file)
+		if raw, err := os.ReadFile(path); err == nil {
+			return template.New(file).Parse(string(raw))
+		}
+	}
+
+	raw, err := fs.ReadFile(builtin, filepath.Join("templates", lang, file))
+	if err != nil {
+		if lang == defaultLang {
+			return nil, fmt.Errorf("emailtpl: template %s introuvable pour %s: %w", file, lang, err)
+		}
+		return loadTemplate(defaultLang, file)
+	}
+	return template.New(file).Parse(string(raw))
+}
@@ -0,0 +1,47 @@
+package emailtpl
+
+import (
+	"html"
+	"strings"
+	"testing"
+)
+
+func TestRender_TextPartIsUnescaped(t *testing.T) {
+	data := Data{
+		Name:    html.EscapeString("Bob & Alice"),
+		Email:   "bob@example.com",
+		Message: html.EscapeString("Prix < 10% de remise ?"),
+	}
+
+	_, _, textBody, err := Render("en", "autoreply", data)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	if strings.Contains(textBody, "&amp;") || strings.Contains(textBody, "&lt;") {
+		t.Errorf("text part still HTML-escaped: %q", textBody)
+	}
+	if !strings.Contains(textBody, "Bob & Alice") {
+		t.Errorf("text part missing unescaped name: %q", textBody)
+	}
+	if !strings.Contains(textBody, "Prix < 10% de remise ?") {
+		t.Errorf("text part missing unescaped message: %q", textBody)
+	}
+}
+
+func TestRender_AdminTextPartKeepsAngleBrackets(t *testing.T) {
+	data := Data{
+		Name:    "Jean",
+		Email:   "jean@example.com",
+		Message: "Appelez-moi <avant 17h> svp, merci",
+	}
+
+	_, _, textBody, err := Render("fr", "admin", data)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	if !strings.Contains(textBody, "Appelez-moi <avant 17h> svp, merci") {
+		t.Errorf("admin text part dropped bracketed content: %q", textBody)
+	}
+}
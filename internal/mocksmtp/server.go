@@ -0,0 +1,184 @@
+// Package mocksmtp fournit un serveur SMTP minimal qui tourne en mémoire,
+// pour tester le code d'envoi d'email sans dépendre d'un vrai relais
+// (ssl0.ovh.net en production). Il comprend juste assez du protocole
+// (EHLO, AUTH PLAIN, MAIL FROM, RCPT TO, DATA) pour satisfaire net/smtp.
+package mocksmtp
+
+import (
+	"bufio"
+	"encoding/base64"
+	"net"
+	"strings"
+	"sync"
+)
+
+// Message est un email capturé par le serveur, tel que reçu sur le fil.
+type Message struct {
+	From string
+	To   []string
+	Data []byte
+}
+
+// Server est un serveur SMTP factice à lancer dans un test avec Start.
+type Server struct {
+	Addr string
+
+	listener net.Listener
+	mu       sync.Mutex
+	messages []Message
+	authUser string
+	authPass string
+	done     chan struct{}
+}
+
+// Start ouvre un listener TCP local et commence à accepter des connexions
+// SMTP en arrière-plan.
+func Start() (*Server, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		Addr:     ln.Addr().String(),
+		listener: ln,
+		done:     make(chan struct{}),
+	}
+	go s.acceptLoop()
+	return s, nil
+}
+
+// Close arrête le serveur et libère le port.
+func (s *Server) Close() error {
+	close(s.done)
+	return s.listener.Close()
+}
+
+// Messages retourne une copie des emails reçus jusqu'ici.
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Message, len(s.messages))
+	copy(out, s.messages)
+	return out
+}
+
+// LastAuth retourne les identifiants du dernier AUTH PLAIN reçu, pour qu'un
+// test puisse vérifier que le client s'est bien authentifié avec ce qui était
+// attendu.
+func (s *Server) LastAuth() (user, pass string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.authUser, s.authPass
+}
+
+func (s *Server) acceptLoop() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			select {
+			case <-s.done:
+				return
+			default:
+				continue
+			}
+		}
+		go s.handleConn(conn)
+	}
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 mocksmtp ready")
+
+	var msg Message
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimRight(line, "\r\n")
+		upper := strings.ToUpper(cmd)
+
+		switch {
+		case strings.HasPrefix(upper, "EHLO"):
+			reply("250-mocksmtp")
+			reply("250 AUTH PLAIN")
+		case strings.HasPrefix(upper, "AUTH PLAIN"):
+			// On ne rejette jamais l'authentification : le mock n'existe que
+			// pour capturer ce que le code client envoie sur le fil, pas pour
+			// se comporter comme un vrai serveur SMTP restrictif.
+			if fields := strings.Fields(cmd); len(fields) == 3 {
+				if user, pass, ok := decodeAuthPlain(fields[2]); ok {
+					s.mu.Lock()
+					s.authUser, s.authPass = user, pass
+					s.mu.Unlock()
+				}
+			}
+			reply("235 Authentication successful")
+		case strings.HasPrefix(upper, "MAIL FROM:"):
+			msg.From = extractAddr(cmd[len("MAIL FROM:"):])
+			reply("250 OK")
+		case strings.HasPrefix(upper, "RCPT TO:"):
+			msg.To = append(msg.To, extractAddr(cmd[len("RCPT TO:"):]))
+			reply("250 OK")
+		case upper == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			data, err := readDotTerminated(r)
+			if err != nil {
+				return
+			}
+			msg.Data = data
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			msg = Message{}
+			reply("250 OK: queued")
+		case upper == "QUIT":
+			reply("221 Bye")
+			return
+		case upper == "RSET":
+			msg = Message{}
+			reply("250 OK")
+		default:
+			reply("500 unrecognized command")
+		}
+	}
+}
+
+func extractAddr(s string) string {
+	s = strings.TrimSpace(s)
+	return strings.Trim(s, "<>")
+}
+
+func readDotTerminated(r *bufio.Reader) ([]byte, error) {
+	var out []byte
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimRight(line, "\r\n") == "." {
+			return out, nil
+		}
+		out = append(out, []byte(line)...)
+	}
+}
+
+// decodeAuthPlain décode le payload base64 d'un AUTH PLAIN
+// ("authzid\x00user\x00pass") et retourne user et pass, utile si un test veut
+// vérifier les identifiants.
+func decodeAuthPlain(payload string) (user, pass string, ok bool) {
+	raw, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", "", false
+	}
+	parts := strings.Split(string(raw), "\x00")
+	if len(parts) != 3 {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
@@ -0,0 +1,30 @@
+package mocksmtp
+
+import (
+	"net"
+	"net/smtp"
+	"testing"
+)
+
+func TestServer_CapturesAuthPlainCredentials(t *testing.T) {
+	s, err := Start()
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer s.Close()
+
+	host, _, err := net.SplitHostPort(s.Addr)
+	if err != nil {
+		t.Fatalf("SplitHostPort: %v", err)
+	}
+	auth := smtp.PlainAuth("", "bot", "secret", host)
+
+	if err := smtp.SendMail(s.Addr, auth, "bot", []string{"client@example.com"}, []byte("Subject: test\r\n\r\ncorps\r\n")); err != nil {
+		t.Fatalf("SendMail: %v", err)
+	}
+
+	user, pass := s.LastAuth()
+	if user != "bot" || pass != "secret" {
+		t.Errorf("LastAuth() = (%q, %q), want (\"bot\", \"secret\")", user, pass)
+	}
+}
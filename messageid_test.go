@@ -0,0 +1,14 @@
+package main
+
+import "testing"
+
+func TestNewMessageID_Unique(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < 1000; i++ {
+		id := newMessageID("admin")
+		if seen[id] {
+			t.Fatalf("newMessageID produced a duplicate: %s", id)
+		}
+		seen[id] = true
+	}
+}
@@ -0,0 +1,43 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// NewFromEnv assemble un Limiter selon les variables d'env :
+//
+//	RATE_LIMIT_MAX        requêtes autorisées par fenêtre (défaut 10)
+//	RATE_LIMIT_WINDOW      durée de la fenêtre, format time.ParseDuration (défaut "15m")
+//	RATE_LIMIT_REDIS_URL   redis://host:port/db ; si défini, bascule sur un
+//	                       RedisLimiter partagé entre plusieurs instances.
+//	                       Sinon, un MemoryLimiter local (avec janitor sur ctx)
+//	                       est utilisé.
+func NewFromEnv(ctx context.Context) (Limiter, error) {
+	max := 10
+	if raw := os.Getenv("RATE_LIMIT_MAX"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("ratelimit: RATE_LIMIT_MAX invalide: %w", err)
+		}
+		max = v
+	}
+
+	window := 15 * time.Minute
+	if raw := os.Getenv("RATE_LIMIT_WINDOW"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return nil, fmt.Errorf("ratelimit: RATE_LIMIT_WINDOW invalide: %w", err)
+		}
+		window = d
+	}
+
+	if redisURL := os.Getenv("RATE_LIMIT_REDIS_URL"); redisURL != "" {
+		return NewRedisLimiter(redisURL, max, window)
+	}
+
+	return NewMemoryLimiter(ctx, max, window), nil
+}
@@ -0,0 +1,101 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryLimiter est un limiteur "sliding log" local : chaque clé garde la
+// liste des horodatages de ses requêtes dans window. Un janitor en tâche de
+// fond purge les clés inactives toutes les minutes, pour que la map ne
+// grossisse pas indéfiniment sous un trafic varié d'IPs.
+type MemoryLimiter struct {
+	max    int
+	window time.Duration
+
+	mu      sync.Mutex
+	buckets map[string][]time.Time
+}
+
+// NewMemoryLimiter construit un MemoryLimiter autorisant max requêtes par
+// window et par clé, et lance son janitor jusqu'à annulation de ctx.
+func NewMemoryLimiter(ctx context.Context, max int, window time.Duration) *MemoryLimiter {
+	l := &MemoryLimiter{
+		max:     max,
+		window:  window,
+		buckets: make(map[string][]time.Time),
+	}
+	go l.runJanitor(ctx)
+	return l
+}
+
+// Allow enregistre une requête pour key si la fenêtre glissante le permet.
+func (l *MemoryLimiter) Allow(ctx context.Context, key string) (Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	fresh := l.freshLocked(key, now)
+
+	if len(fresh) >= l.max {
+		l.buckets[key] = fresh
+		resetAt := fresh[0].Add(l.window)
+		return Result{
+			Allowed:    false,
+			Limit:      l.max,
+			Remaining:  0,
+			ResetAt:    resetAt,
+			RetryAfter: resetAt.Sub(now),
+		}, nil
+	}
+
+	fresh = append(fresh, now)
+	l.buckets[key] = fresh
+	return Result{
+		Allowed:   true,
+		Limit:     l.max,
+		Remaining: l.max - len(fresh),
+		ResetAt:   fresh[0].Add(l.window),
+	}, nil
+}
+
+// freshLocked retourne les horodatages de key encore dans la fenêtre,
+// l'appelant doit détenir l.mu.
+func (l *MemoryLimiter) freshLocked(key string, now time.Time) []time.Time {
+	reqs := l.buckets[key]
+	fresh := reqs[:0]
+	for _, t := range reqs {
+		if now.Sub(t) < l.window {
+			fresh = append(fresh, t)
+		}
+	}
+	return fresh
+}
+
+// runJanitor tourne jusqu'à annulation de ctx et purge les clés expirées
+// toutes les minutes.
+func (l *MemoryLimiter) runJanitor(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			l.gc()
+		}
+	}
+}
+
+// gc supprime les clés dont la dernière requête est plus vieille que window.
+func (l *MemoryLimiter) gc() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	for key, reqs := range l.buckets {
+		if len(reqs) == 0 || now.Sub(reqs[len(reqs)-1]) >= l.window {
+			delete(l.buckets, key)
+		}
+	}
+}
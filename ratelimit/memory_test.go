@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryLimiter_AllowsUpToMaxThenBlocks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l := NewMemoryLimiter(ctx, 3, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		res, err := l.Allow(ctx, "1.2.3.4")
+		if err != nil {
+			t.Fatalf("Allow: %v", err)
+		}
+		if !res.Allowed {
+			t.Fatalf("requête %d refusée, devrait passer", i)
+		}
+		if res.Limit != 3 {
+			t.Errorf("Limit = %d, want 3", res.Limit)
+		}
+	}
+
+	res, err := l.Allow(ctx, "1.2.3.4")
+	if err != nil {
+		t.Fatalf("Allow: %v", err)
+	}
+	if res.Allowed {
+		t.Fatal("4e requête acceptée, devrait être bloquée")
+	}
+	if res.RetryAfter <= 0 {
+		t.Errorf("RetryAfter = %v, want > 0", res.RetryAfter)
+	}
+}
+
+func TestMemoryLimiter_KeysAreIndependent(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l := NewMemoryLimiter(ctx, 1, time.Minute)
+
+	if res, err := l.Allow(ctx, "1.1.1.1"); err != nil || !res.Allowed {
+		t.Fatalf("Allow(1.1.1.1) = %+v, %v", res, err)
+	}
+	if res, err := l.Allow(ctx, "2.2.2.2"); err != nil || !res.Allowed {
+		t.Fatalf("Allow(2.2.2.2) = %+v, %v", res, err)
+	}
+	if res, _ := l.Allow(ctx, "1.1.1.1"); res.Allowed {
+		t.Fatal("1.1.1.1 devrait déjà avoir atteint sa limite")
+	}
+}
@@ -0,0 +1,26 @@
+// Package ratelimit limite le nombre de requêtes autorisées par clé (une IP,
+// typiquement) sur une fenêtre glissante, avec deux backends interchangeables
+// : un MemoryLimiter pour une instance unique, et un RedisLimiter partagé
+// entre plusieurs réplicas derrière un load balancer.
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Result décrit l'issue d'un Allow : si la requête passe, et les métadonnées
+// à exposer au client via les en-têtes X-RateLimit-*/Retry-After.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAt    time.Time
+	RetryAfter time.Duration
+}
+
+// Limiter décide si key (une IP) peut effectuer une requête de plus dans la
+// fenêtre courante.
+type Limiter interface {
+	Allow(ctx context.Context, key string) (Result, error)
+}
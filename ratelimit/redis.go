@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisLimiter implémente un compteur à fenêtre glissante partagé entre
+// plusieurs instances du service, selon le motif classique INCR+EXPIRE : la
+// fenêtre courante (rl:{key}:{floor(now/window)}) est incrémentée, et son
+// total est pondéré avec celui de la fenêtre précédente au prorata du temps
+// qu'il en reste, pour lisser l'effet de bord entre deux fenêtres fixes.
+type RedisLimiter struct {
+	client *redis.Client
+	max    int
+	window time.Duration
+}
+
+// NewRedisLimiter construit un RedisLimiter autorisant max requêtes par
+// window et par clé, sur le serveur Redis décrit par rawURL
+// (redis://[user:pass@]host:port/db).
+func NewRedisLimiter(rawURL string, max int, window time.Duration) (*RedisLimiter, error) {
+	opts, err := redis.ParseURL(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("ratelimit: RATE_LIMIT_REDIS_URL invalide: %w", err)
+	}
+	return &RedisLimiter{client: redis.NewClient(opts), max: max, window: window}, nil
+}
+
+// Allow incrémente le compteur de la fenêtre courante de key et décide selon
+// la somme pondérée avec la fenêtre précédente.
+func (l *RedisLimiter) Allow(ctx context.Context, key string) (Result, error) {
+	now := time.Now()
+	windowNanos := int64(l.window)
+	idx := now.UnixNano() / windowNanos
+	fraction := float64(now.UnixNano()%windowNanos) / float64(windowNanos)
+
+	currKey := fmt.Sprintf("rl:%s:%d", key, idx)
+	prevKey := fmt.Sprintf("rl:%s:%d", key, idx-1)
+
+	pipe := l.client.TxPipeline()
+	incr := pipe.Incr(ctx, currKey)
+	pipe.Expire(ctx, currKey, 2*l.window)
+	prevGet := pipe.Get(ctx, prevKey)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return Result{}, fmt.Errorf("ratelimit: pipeline redis: %w", err)
+	}
+
+	curr := float64(incr.Val())
+	prev, err := prevGet.Float64()
+	if err != nil && err != redis.Nil {
+		return Result{}, fmt.Errorf("ratelimit: lecture fenêtre précédente: %w", err)
+	}
+
+	weighted := prev*(1-fraction) + curr
+	resetAt := time.Unix(0, (idx+1)*windowNanos)
+
+	if weighted > float64(l.max) {
+		return Result{
+			Allowed:    false,
+			Limit:      l.max,
+			Remaining:  0,
+			ResetAt:    resetAt,
+			RetryAfter: resetAt.Sub(now),
+		}, nil
+	}
+
+	remaining := l.max - int(weighted)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return Result{Allowed: true, Limit: l.max, Remaining: remaining, ResetAt: resetAt}, nil
+}
+
+// Close ferme la connexion au serveur Redis.
+func (l *RedisLimiter) Close() error {
+	return l.client.Close()
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// errorCode énumère les codes d'erreur stables renvoyés par /api/contact :
+// le frontend peut les comparer sans parser message, qui lui reste libre
+// d'être localisé ou reformulé sans casser l'intégration.
+type errorCode string
+
+const (
+	codeMethodNotAllowed errorCode = "method_not_allowed"
+	codeRateLimited      errorCode = "rate_limited"
+	codeInvalidRequest   errorCode = "invalid_request"
+	codeTurnstileFailed  errorCode = "turnstile_failed"
+	codeCaptchaFailed    errorCode = "captcha_failed"
+	codeAntiBotMissing   errorCode = "antibot_missing"
+	codeValidationFailed errorCode = "validation_failed"
+	codeLinksNotAllowed  errorCode = "links_not_allowed"
+	codeInternalError    errorCode = "internal_error"
+)
+
+// apiResponse est l'enveloppe JSON renvoyée par /api/contact, en succès comme
+// en erreur. Fields n'est rempli que pour code=validation_failed.
+type apiResponse struct {
+	OK      bool              `json:"ok"`
+	Code    errorCode         `json:"code,omitempty"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+// writeJSON sérialise resp avec le Content-Type JSON et le code HTTP status.
+func writeJSON(w http.ResponseWriter, status int, resp apiResponse) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// writeError envoie une apiResponse d'erreur avec status, code et message.
+func writeError(w http.ResponseWriter, status int, code errorCode, message string) {
+	writeJSON(w, status, apiResponse{OK: false, Code: code, Message: message})
+}
+
+// writeValidationError envoie une erreur validation_failed, avec le tag de
+// validation ayant échoué pour chaque champ fautif.
+func writeValidationError(w http.ResponseWriter, err validator.ValidationErrors) {
+	fields := make(map[string]string, len(err))
+	for _, fe := range err {
+		fields[fe.Field()] = fe.Tag()
+	}
+	writeJSON(w, http.StatusBadRequest, apiResponse{
+		OK:      false,
+		Code:    codeValidationFailed,
+		Message: "Champs invalides.",
+		Fields:  fields,
+	})
+}
@@ -1,29 +1,49 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"html"
 	"log"
+	"math"
+	"net"
 	"net/http"
-	"net/smtp"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
-	"sync"
 	"time"
 
+	"github.com/SebbeMercier/Eldocam_Back_end/antibot"
+	"github.com/SebbeMercier/Eldocam_Back_end/courier"
+	"github.com/SebbeMercier/Eldocam_Back_end/emailtpl"
+	"github.com/SebbeMercier/Eldocam_Back_end/ratelimit"
 	"github.com/go-playground/validator/v10"
 	"github.com/joho/godotenv"
 )
 
 var (
-	validate       = validator.New()
-	rateLimitMutex sync.Mutex
-	rateLimits     = make(map[string][]time.Time)
+	validate = validator.New()
 
 	urlRegex      = regexp.MustCompile(`(https?://[^\s]+)|(www\.[^\s]+)|([a-z0-9\-]+\.[a-z]{2,})`)
-	cyrillicRegex = regexp.MustCompile("[\u0400-\u04FF]")
+	cyrillicRegex = regexp.MustCompile("[Ѐ-ӿ]")
+
+	// courierQueue reçoit les mails admin/auto-réponse à dispatcher de façon
+	// asynchrone, pour ne jamais bloquer la réponse HTTP sur le SMTP.
+	courierQueue *courier.BoltQueue
+
+	// turnstileVerifier et captchaVerifier sont les deux antibot.Verifier
+	// proposés au client : Turnstile par défaut, le CAPTCHA image en repli
+	// quand le client ne peut pas (ou ne veut pas) passer par Cloudflare.
+	turnstileVerifier *antibot.TurnstileVerifier
+	captchaVerifier   = antibot.NewCaptchaVerifier()
+
+	// rateLimiter borne le nombre de soumissions par IP ; voir ratelimit.NewFromEnv
+	// pour le choix du backend (mémoire locale ou Redis partagé).
+	rateLimiter ratelimit.Limiter
 )
 
 // Structure du formulaire
@@ -35,185 +55,308 @@ type ContactForm struct {
 	Message  string `validate:"required,min=3,max=5000"`
 }
 
-// --- Vérification Turnstile ---
-func verifyTurnstile(token string, remoteIP string) bool {
-	secret := os.Getenv("TURNSTILE_SECRET")
-	if secret == "" {
-		log.Println("⚠️ Variable TURNSTILE_SECRET non définie")
-		return false
+// --- Vérification anti-bot ---
+// verifyAntiBot choisit le Verifier à utiliser selon ce que le client a
+// envoyé : cf-turnstile-response pour passer par Turnstile, ou la paire
+// captcha-id/captcha-solution pour le repli en CAPTCHA image. Elle retourne
+// un errorCode vide en cas de succès, ou le code précis à renvoyer au client
+// sinon (aucun des deux n'étant présent vaut codeAntiBotMissing).
+func verifyAntiBot(r *http.Request, remoteIP string) errorCode {
+	ctx := r.Context()
+
+	if token := r.FormValue("cf-turnstile-response"); token != "" {
+		ok, err := turnstileVerifier.Verify(ctx, token, remoteIP)
+		if err != nil {
+			log.Println("Erreur vérification Turnstile:", err)
+		}
+		if !ok {
+			return codeTurnstileFailed
+		}
+		return ""
 	}
 
-	resp, err := http.PostForm("https://challenges.cloudflare.com/turnstile/v0/siteverify", map[string][]string{
-		"secret":   {secret},
-		"response": {token},
-		"remoteip": {remoteIP},
-	})
+	id, solution := r.FormValue("captcha-id"), r.FormValue("captcha-solution")
+	if id != "" && solution != "" {
+		ok, err := captchaVerifier.Verify(ctx, id+":"+solution, remoteIP)
+		if err != nil {
+			log.Println("Erreur vérification CAPTCHA:", err)
+		}
+		if !ok {
+			return codeCaptchaFailed
+		}
+		return ""
+	}
+
+	return codeAntiBotMissing
+}
+
+// setRateLimitHeaders expose le résultat d'un rateLimiter.Allow au client,
+// qu'il ait été accepté ou rejeté, pour qu'il puisse adapter son rythme
+// d'envoi.
+func setRateLimitHeaders(w http.ResponseWriter, res ratelimit.Result) {
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(res.ResetAt.Unix(), 10))
+	if !res.Allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(res.RetryAfter.Seconds()))))
+	}
+}
+
+// successTexts donne, par langue résolue, le message de confirmation affiché
+// au soumissionnaire dans la réponse HTTP (pas dans l'email lui-même).
+var successTexts = map[string]string{
+	"nl": "Je bericht is goed ontvangen.",
+	"en": "Your message has been received.",
+	"fr": "Votre message a bien été envoyé.",
+}
+
+// --- Construction des messages admin + auto-réponse ---
+// buildMessages ne fait plus l'envoi lui-même : il rend les templates
+// emailtpl puis produit les deux Message à enfiler dans la courierQueue, qui
+// s'occupe du SMTP en tâche de fond.
+func buildMessages(form ContactForm, mailUser, adminTo string) (admin, reply courier.Message, successText string, err error) {
+	lang := emailtpl.ResolveLanguage(form.Language)
+
+	// Le mail admin reste du texte brut : ni le nom ni le message ne doivent
+	// être échappés, sous peine d'afficher des entités HTML à l'admin.
+	adminData := emailtpl.Data{
+		Name:    form.Name,
+		Email:   form.Email,
+		Tel:     form.Tel,
+		Message: form.Message,
+	}
+	adminSubject, _, adminText, err := emailtpl.Render(lang, "admin", adminData)
 	if err != nil {
-		log.Println("Erreur requête Turnstile:", err)
-		return false
+		return courier.Message{}, courier.Message{}, "", fmt.Errorf("rendu du mail admin: %w", err)
+	}
+	admin = courier.Message{
+		ID:       newMessageID("admin"),
+		Channel:  "smtp",
+		To:       adminTo,
+		From:     mailUser,
+		ReplyTo:  form.Email,
+		Subject:  adminSubject,
+		TextBody: adminText,
+		Priority: courier.PriorityNormal,
+	}
+	if urgentMessage(form.Message) {
+		admin.Priority = courier.PriorityUrgent
 	}
-	defer resp.Body.Close()
 
-	var data struct {
-		Success bool `json:"success"`
+	escapedName := html.EscapeString(form.Name)
+	replyData := emailtpl.Data{
+		Name:    escapedName,
+		Email:   form.Email,
+		Tel:     form.Tel,
+		Message: strings.ReplaceAll(html.EscapeString(form.Message), "\n", "<br>"),
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		log.Println("Erreur décodage Turnstile:", err)
-		return false
+	replySubject, replyHTML, replyText, err := emailtpl.Render(lang, "autoreply", replyData)
+	if err != nil {
+		return courier.Message{}, courier.Message{}, "", fmt.Errorf("rendu de l'auto-réponse: %w", err)
+	}
+	reply = courier.Message{
+		ID:       newMessageID("reply"),
+		Channel:  "smtp",
+		To:       form.Email,
+		From:     mailUser,
+		Subject:  replySubject,
+		TextBody: replyText,
+		HTMLBody: replyHTML,
+		Priority: courier.PriorityNormal,
 	}
-	return data.Success
-}
 
-// --- Rate limit : 10 requêtes / 15 min / IP ---
-func allowRequest(ip string) bool {
-	rateLimitMutex.Lock()
-	defer rateLimitMutex.Unlock()
+	successText = successTexts[lang]
+	if successText == "" {
+		successText = successTexts[emailtpl.ResolveLanguage("")]
+	}
 
-	now := time.Now()
-	window := 15 * time.Minute
-	max := 10
+	return admin, reply, successText, nil
+}
 
-	reqs := rateLimits[ip]
-	newReqs := []time.Time{}
-	for _, t := range reqs {
-		if now.Sub(t) < window {
-			newReqs = append(newReqs, t)
+// urgentMessage détecte un mot-clé prioritaire dans le message pour router
+// une alerte admin par SMS en plus de l'email (cf. courier.UrgentKeywords).
+func urgentMessage(message string) bool {
+	lower := strings.ToLower(message)
+	for _, kw := range courier.UrgentKeywords {
+		if strings.Contains(lower, kw) {
+			return true
 		}
 	}
-	if len(newReqs) >= max {
-		return false
+	return false
+}
+
+// buildSMSAlert produit le Message SMS envoyé à l'admin (ADMIN_PHONE) en
+// complément de l'email quand urgentMessage détecte un mot-clé prioritaire.
+func buildSMSAlert(form ContactForm, adminPhone string) courier.Message {
+	return courier.Message{
+		ID:       newMessageID("sms"),
+		Channel:  "sms",
+		To:       adminPhone,
+		Subject:  "Message urgent",
+		TextBody: fmt.Sprintf("Message urgent de %s (%s): %s", form.Name, form.Email, form.Message),
+		Priority: courier.PriorityUrgent,
 	}
-	newReqs = append(newReqs, now)
-	rateLimits[ip] = newReqs
-	return true
 }
 
-// --- Envoi d'email (inchangé sauf simplifié) ---
-func sendEmail(form ContactForm, mailUser, mailPass, adminTo string) (string, error) {
-	auth := smtp.PlainAuth("", mailUser, mailPass, "ssl0.ovh.net")
+// trustedProxies liste les CIDR (TRUSTED_PROXY_CIDRS, séparés par des
+// virgules) dont on accepte de croire X-Forwarded-For/X-Real-IP. Tant que
+// RemoteAddr n'appartient à aucun de ces réseaux, ces en-têtes sont ignorés :
+// sans ça, n'importe quel client pourrait forger une IP différente à chaque
+// requête et se refaire un nouveau quota de rate limiting à volonté.
+var trustedProxies = parseTrustedProxies(os.Getenv("TRUSTED_PROXY_CIDRS"))
+
+// parseTrustedProxies découpe raw ("10.0.0.0/8,172.16.0.0/12") en []*net.IPNet,
+// en ignorant (avec un log) les entrées qui ne sont pas des CIDR valides.
+func parseTrustedProxies(raw string) []*net.IPNet {
+	var nets []*net.IPNet
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		_, n, err := net.ParseCIDR(field)
+		if err != nil {
+			log.Printf("TRUSTED_PROXY_CIDRS: entrée invalide %q ignorée: %v", field, err)
+			continue
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
 
-	escapedMsg := html.EscapeString(form.Message)
-	escapedMsg = strings.ReplaceAll(escapedMsg, "\n", "<br>")
+// isTrustedProxy indique si host (une IP, sans port) appartient à trustedProxies.
+func isTrustedProxy(host string) bool {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, n := range trustedProxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
 
-	// --- Mail à l'admin ---
-	adminBody := fmt.Sprintf(
-		"To: %s\r\nSubject: Prise de contact de %s\r\nReply-To: %s\r\n\r\nNom: %s\nEmail: %s\nTel: %s\nMessage:\n%s",
-		adminTo, form.Name, form.Email, form.Name, form.Email, form.Tel, form.Message,
-	)
+// newMessageID génère un identifiant unique pour courier.Message.ID, qui sert
+// de clé dans la BoltQueue : deux messages distincts qui obtiendraient la
+// même clé s'écraseraient silencieusement l'un l'autre. Un timestamp seul
+// n'est pas garanti unique (deux soumissions concurrentes peuvent tomber sur
+// la même nanoseconde), d'où les 16 octets aléatoires de crypto/rand.
+func newMessageID(prefix string) string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return prefix + "-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return prefix + "-" + hex.EncodeToString(b[:])
+}
 
-	err := smtp.SendMail("ssl0.ovh.net:587", auth, mailUser, []string{adminTo}, []byte(adminBody))
-	if err != nil {
-		return "", err
-	}
-
-	// --- Auto-réponse selon la langue ---
-	var subject, htmlBody, successText string
-
-	switch strings.ToLower(form.Language) {
-	case "nl":
-		subject = "Automatisch antwoord"
-		htmlBody = fmt.Sprintf(`
-			<div style="font-family: Arial, sans-serif; padding: 20px;">
-				<h2>Hallo %s,</h2>
-				<p>Bedankt voor uw bericht! We hebben uw aanvraag ontvangen.</p>
-				<blockquote style="border-left: 4px solid #e80000; margin: 10px 0; padding-left: 10px;">%s</blockquote>
-				<p>We nemen zo snel mogelijk contact met u op.</p>
-				<p style="font-size:12px; color:#888;">— Het Eldocam-team</p>
-			</div>`, html.EscapeString(form.Name), escapedMsg)
-		successText = "Je bericht is goed ontvangen."
-
-	case "en":
-		subject = "Automatic reply"
-		htmlBody = fmt.Sprintf(`
-			<div style="font-family: Arial, sans-serif; padding: 20px;">
-				<h2>Hello %s,</h2>
-				<p>Thank you for contacting us! We have received your message.</p>
-				<blockquote style="border-left: 4px solid #e80000; margin: 10px 0; padding-left: 10px;">%s</blockquote>
-				<p>We will get back to you as soon as possible.</p>
-				<p style="font-size:12px; color:#888;">— The Eldocam team</p>
-			</div>`, html.EscapeString(form.Name), escapedMsg)
-		successText = "Your message has been received."
-
-	default:
-		subject = "Réponse automatique"
-		htmlBody = fmt.Sprintf(`
-			<div style="font-family: Arial, sans-serif; padding: 20px;">
-				<h2>Bonjour %s,</h2>
-				<p>Merci de nous avoir contactés ! Nous avons bien reçu votre message.</p>
-				<blockquote style="border-left: 4px solid #e80000; margin: 10px 0; padding-left: 10px;">%s</blockquote>
-				<p>Nous reviendrons vers vous dans les plus brefs délais.</p>
-				<p style="font-size:12px; color:#888;">— L’équipe Eldocam</p>
-			</div>`, html.EscapeString(form.Name), escapedMsg)
-		successText = "Votre message a bien été envoyé."
-	}
-
-	// --- Envoi de l'auto-réponse ---
-	clientBody := fmt.Sprintf(
-		"From: %s\r\nTo: %s\r\nSubject: %s\r\n"+
-			"MIME-Version: 1.0\r\nContent-Type: text/html; charset=UTF-8\r\n\r\n%s",
-		mailUser, form.Email, subject, htmlBody,
-	)
-
-	err = smtp.SendMail("ssl0.ovh.net:587", auth, mailUser, []string{form.Email}, []byte(clientBody))
+// clientIP extrait l'adresse IP du client à utiliser pour le rate limiting et
+// la vérification anti-bot. X-Forwarded-For (son premier maillon, le plus
+// proche du client) et X-Real-IP ne sont pris en compte que si RemoteAddr est
+// lui-même un proxy de confiance (cf. trustedProxies) ; sinon on renvoie
+// RemoteAddr dépouillé de son port, pour qu'un client direct ne puisse pas
+// usurper ces en-têtes.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
 	if err != nil {
-		log.Println("⚠️ Erreur auto-réponse :", err)
-	} else {
-		log.Println("✅ Auto-réponse envoyée à", form.Email)
+		host = r.RemoteAddr
+	}
+	if !isTrustedProxy(host) {
+		return host
+	}
+	if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+		if ip := strings.TrimSpace(strings.Split(fwd, ",")[0]); ip != "" {
+			return ip
+		}
+	}
+	if real := r.Header.Get("X-Real-IP"); real != "" {
+		return real
 	}
+	return host
+}
 
-	return successText, nil
+// antiBotMessages donne le message humain associé à chaque errorCode que
+// verifyAntiBot peut retourner.
+var antiBotMessages = map[errorCode]string{
+	codeTurnstileFailed: "Vérification Turnstile échouée.",
+	codeCaptchaFailed:   "Solution du CAPTCHA incorrecte.",
+	codeAntiBotMissing:  "Vérification anti-bot manquante.",
 }
 
 // --- Handler principal ---
 func contactHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Méthode non autorisée", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, codeMethodNotAllowed, "Méthode non autorisée.")
 		return
 	}
 
-	ip := r.RemoteAddr
-	if !allowRequest(ip) {
-		http.Error(w, "Trop de requêtes. Réessayez plus tard.", http.StatusTooManyRequests)
+	ip := clientIP(r)
+	res, err := rateLimiter.Allow(r.Context(), ip)
+	if err != nil {
+		log.Println("Erreur rate limiter:", err)
+		writeError(w, http.StatusInternalServerError, codeInternalError, "Erreur interne.")
+		return
+	}
+	setRateLimitHeaders(w, res)
+	if !res.Allowed {
+		writeError(w, http.StatusTooManyRequests, codeRateLimited, "Trop de requêtes. Réessayez plus tard.")
 		return
 	}
 
 	var form ContactForm
-	err := decodeJSON(r, &form)
+	err = decodeJSON(r, &form)
 	log.Printf("DEBUG form: %+v\n", form)
 	if err != nil {
-		http.Error(w, "Champs invalides", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, codeInvalidRequest, "Champs invalides.")
 		return
 	}
 
-	// 🔒 Vérification Turnstile
-	token := r.FormValue("cf-turnstile-response")
-	if token == "" || !verifyTurnstile(token, ip) {
-		http.Error(w, "Vérification Turnstile échouée.", http.StatusBadRequest)
+	// 🔒 Vérification anti-bot (Turnstile, ou CAPTCHA image en repli)
+	if code := verifyAntiBot(r, ip); code != "" {
+		writeError(w, http.StatusBadRequest, code, antiBotMessages[code])
 		return
 	}
 
 	// Validation de contenu
-	if err := validate.Struct(form); err != nil {
-		http.Error(w, "Champs invalides", http.StatusBadRequest)
+	if verr, ok := validate.Struct(form).(validator.ValidationErrors); ok {
+		writeValidationError(w, verr)
 		return
 	}
 	if urlRegex.MatchString(form.Message) {
-		http.Error(w, "L'envoi de liens n'est pas autorisé.", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, codeLinksNotAllowed, "L'envoi de liens n'est pas autorisé.")
 		return
 	}
 
 	mailUser := os.Getenv("MAIL_USER")
-	mailPass := os.Getenv("MAIL_PASS")
 	adminTo := os.Getenv("ADMIN_TO")
 
-	successText, err := sendEmail(form, mailUser, mailPass, adminTo)
+	adminMsg, replyMsg, successText, err := buildMessages(form, mailUser, adminTo)
 	if err != nil {
-		log.Println("Erreur envoi email:", err)
-		http.Error(w, "Erreur lors de l'envoi.", http.StatusInternalServerError)
+		log.Println("Erreur rendu des templates email:", err)
+		writeError(w, http.StatusInternalServerError, codeInternalError, "Erreur lors de l'envoi.")
+		return
+	}
+	if err := courierQueue.Enqueue(r.Context(), adminMsg); err != nil {
+		log.Println("Erreur mise en file (admin):", err)
+		writeError(w, http.StatusInternalServerError, codeInternalError, "Erreur lors de l'envoi.")
 		return
 	}
+	if err := courierQueue.Enqueue(r.Context(), replyMsg); err != nil {
+		log.Println("Erreur mise en file (auto-réponse):", err)
+		// La notification admin est déjà en file, on ne fait pas échouer la requête
+		// pour autant : l'auto-réponse sera juste manquante pour cette soumission.
+	}
+
+	if adminMsg.Priority == courier.PriorityUrgent && courierQueue.HasRoute("sms") {
+		if adminPhone := os.Getenv("ADMIN_PHONE"); adminPhone != "" {
+			if err := courierQueue.Enqueue(r.Context(), buildSMSAlert(form, adminPhone)); err != nil {
+				log.Println("Erreur mise en file (sms):", err)
+			}
+		}
+	}
 
-	fmt.Fprint(w, successText)
+	writeJSON(w, http.StatusOK, apiResponse{OK: true, Message: successText})
 }
 
 // --- Utilitaire decodeJSON ---
@@ -242,12 +385,34 @@ func decodeJSON(r *http.Request, v interface{}) error {
 func main() {
 	_ = godotenv.Load()
 
-	http.HandleFunc("/api/contact", func(w http.ResponseWriter, r *http.Request) {
+	queue, err := courier.NewFromEnv()
+	if err != nil {
+		log.Fatal("Initialisation courier: ", err)
+	}
+	defer queue.Close()
+	courierQueue = queue
+
+	turnstileVerifier = antibot.NewTurnstileVerifier(os.Getenv("TURNSTILE_SECRET"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go courierQueue.Run(ctx)
+
+	limiter, err := ratelimit.NewFromEnv(ctx)
+	if err != nil {
+		log.Fatal("Initialisation rate limiter: ", err)
+	}
+	rateLimiter = limiter
+
+	http.HandleFunc("/api/contact", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("X-Content-Type-Options", "nosniff")
 		w.Header().Set("X-Frame-Options", "DENY")
 		w.Header().Set("X-XSS-Protection", "1; mode=block")
 		contactHandler(w, r)
-	})
+	}))
+	http.HandleFunc("/api/captcha/new", corsMiddleware(captchaVerifier.NewChallengeHandler))
+	http.HandleFunc("/api/captcha/", corsMiddleware(captchaVerifier.ImageHandler))
+	http.HandleFunc("/api/openapi.yaml", openAPIHandler)
 
 	addr := "127.0.0.1:3000"
 	log.Println("🚀 Serveur en écoute sur http://" + addr)